@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+// cmdCluster implements the "lxc cluster" family of commands for managing cluster members.
+type cmdCluster struct {
+	global *cmdGlobal
+}
+
+func (c *cmdCluster) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "cluster"
+	cmd.Short = i18n.G("Manage cluster members")
+	cmd.Long = i18n.G(`Manage cluster members`)
+
+	// Add
+	clusterAddCmd := cmdClusterAdd{global: c.global, cluster: c}
+	cmd.AddCommand(clusterAddCmd.Command())
+
+	return cmd
+}
+
+// ParseServers resolves a "[<remote>:]<member name>" argument into the resources the cluster
+// subcommands operate on.
+func (c *cmdCluster) ParseServers(args ...string) ([]remoteResource, error) {
+	return c.global.ParseServers(args...)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdClusterAdd struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("add", i18n.G("[<remote>:]<member name>"))
+	cmd.Short = i18n.G("Mint a join token for a new cluster member")
+	cmd.Long = i18n.G(
+		`Mint a join token for a new cluster member
+
+The resulting token is a single opaque string that can be pasted into
+"lxd init" on the new member. It carries everything the new member needs to
+join the cluster (the address and certificate fingerprint of this member, and
+a one-time secret to authenticate the join request), so that no trust
+password needs to be shared out of band.`)
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterAdd) Run(cmd *cobra.Command, args []string) error {
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resources, err := c.cluster.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster member name"))
+	}
+
+	client := resource.server
+
+	op, err := client.CreateClusterMember(api.ClusterMembersPost{ServerName: resource.name})
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	// The daemon returns the minted token as the metadata of the operation it runs to record
+	// the pending cluster member.
+	data, err := json.Marshal(op.Get().Metadata)
+	if err != nil {
+		return err
+	}
+
+	token := api.ClusterMemberJoinToken{}
+	err = json.Unmarshal(data, &token)
+	if err != nil {
+		return fmt.Errorf(i18n.G("Failed to parse join token: %w"), err)
+	}
+
+	fmt.Println(token.String())
+
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package discovery implements LAN discovery of other LXD servers over mDNS/zeroconf, so that
+// "lxd init" can offer a menu of cluster join candidates instead of requiring the user to type an
+// address.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// serviceType is the mDNS/DNS-SD service type advertised by LXD servers.
+const serviceType = "_lxd._tcp"
+
+// Peer describes a single LXD server discovered on the LAN.
+type Peer struct {
+	ServerName  string
+	Address     string
+	Fingerprint string
+}
+
+// Advertise publishes this server on the local network over mDNS so that other nodes running
+// "lxd init" can discover it. The returned io.Closer-like Shutdown function must be called to
+// withdraw the advertisement.
+//
+// address is "core.https_address" as the user configured it (host or host:port); the record is
+// pinned to it via RegisterProxy rather than left to zeroconf's own interface auto-detection, so
+// that on a multi-homed host, or when the user chose a non-default address, peers discover the
+// address LXD is actually listening on.
+func Advertise(serverName string, address string, port int, fingerprint string) (*zeroconf.Server, error) {
+	txt := []string{
+		fmt.Sprintf("name=%s", serverName),
+		fmt.Sprintf("fingerprint=%s", fingerprint),
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	var ips []string
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []string{host}
+	}
+
+	server, err := zeroconf.RegisterProxy(serverName, serviceType, "local.", port, host, ips, txt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to advertise over mDNS: %w", err)
+	}
+
+	return server, nil
+}
+
+// Browse looks for other LXD servers advertising themselves over mDNS for the given context
+// (typically bounded with a short timeout) and returns whatever peers answered in time.
+func Browse(ctx context.Context) ([]Peer, error) {
+	resolver, err := zeroconf.NewResolver(nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to initialize mDNS resolver: %w", err)
+	}
+
+	entries := make(chan *zeroconf.ServiceEntry)
+	peers := []Peer{}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for entry := range entries {
+			peer := Peer{
+				ServerName: entry.Instance,
+			}
+
+			if len(entry.AddrIPv4) > 0 {
+				peer.Address = entry.AddrIPv4[0].String()
+			} else if len(entry.AddrIPv6) > 0 {
+				peer.Address = entry.AddrIPv6[0].String()
+			} else {
+				continue
+			}
+
+			if entry.Port != 0 {
+				peer.Address = peer.Address + ":" + strconv.Itoa(entry.Port)
+			}
+
+			for _, record := range entry.Text {
+				if len(record) > len("fingerprint=") && record[:len("fingerprint=")] == "fingerprint=" {
+					peer.Fingerprint = record[len("fingerprint="):]
+				}
+			}
+
+			peers = append(peers, peer)
+		}
+	}()
+
+	err = resolver.Browse(ctx, serviceType, "local.", entries)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to browse for LXD servers: %w", err)
+	}
+
+	<-ctx.Done()
+	<-done
+
+	return peers, nil
+}
@@ -0,0 +1,124 @@
+// Package client is a minimal MAAS 2.x REST client, just capable enough to let "lxd init" list
+// the fabrics, VLANs and subnets of a MAAS server so the user can pick one from a menu.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Subnet is a MAAS subnet, flattened with just the fields "lxd init" needs to build a menu.
+type Subnet struct {
+	CIDR   string
+	Fabric string
+	VLAN   string
+	Family string // "inet" or "inet6", matching MAAS' own terminology
+}
+
+// Client is a small authenticated MAAS API client.
+type Client struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// New returns a MAAS API client for the given API URL and key (in MAAS'
+// "consumer:token:secret" format).
+func New(url string, apiKey string) *Client {
+	return &Client{
+		url:        strings.TrimRight(url, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+	}
+}
+
+// ListSubnets fetches the full list of subnets known to the MAAS server, along with the fabric
+// and VLAN they belong to.
+func (c *Client) ListSubnets(ctx context.Context) ([]Subnet, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/2.0/subnets/", c.url), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.sign(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to reach MAAS server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MAAS server responded with status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []maasSubnet
+	err = json.Unmarshal(body, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse MAAS subnets: %w", err)
+	}
+
+	return subnetsFromRaw(raw), nil
+}
+
+// maasSubnet is the shape of a single entry in MAAS' "/api/2.0/subnets/" response, trimmed down
+// to the fields subnetsFromRaw needs.
+type maasSubnet struct {
+	CIDR string `json:"cidr"`
+	VLAN struct {
+		Name   string `json:"name"`
+		Fabric string `json:"fabric"`
+	} `json:"vlan"`
+}
+
+// subnetsFromRaw flattens the raw MAAS API subnet entries into our own Subnet type, deriving
+// Family from whether the CIDR looks like IPv4 or IPv6.
+func subnetsFromRaw(raw []maasSubnet) []Subnet {
+	subnets := make([]Subnet, 0, len(raw))
+	for _, entry := range raw {
+		family := "inet"
+		if strings.Contains(entry.CIDR, ":") {
+			family = "inet6"
+		}
+
+		subnets = append(subnets, Subnet{
+			CIDR:   entry.CIDR,
+			Fabric: entry.VLAN.Fabric,
+			VLAN:   entry.VLAN.Name,
+			Family: family,
+		})
+	}
+
+	return subnets
+}
+
+// sign attaches the MAAS OAuth1 "PLAINTEXT"-style authorization header built from the API key.
+func (c *Client) sign(req *http.Request) {
+	parts := strings.SplitN(c.apiKey, ":", 3)
+	if len(parts) != 3 {
+		return
+	}
+
+	consumerKey, token, tokenSecret := parts[0], parts[1], parts[2]
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`OAuth oauth_version="1.0", oauth_signature_method="PLAINTEXT", oauth_consumer_key="%s", oauth_token="%s", oauth_signature="%%26%s"`,
+		oauthEscape(consumerKey), oauthEscape(token), oauthEscape(tokenSecret)))
+}
+
+// oauthEscape percent-encodes a value for inclusion in an OAuth1 Authorization header, per
+// RFC 5849 section 3.6 (the same percent-encoding as RFC 3986, which notably reserves space as
+// "%20" rather than url.QueryEscape's "+").
+func oauthEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestListSubnetsParsesFamilyFromCIDR(t *testing.T) {
+	body := []byte(`[
+		{"cidr": "10.0.0.0/24", "vlan": {"name": "untagged", "fabric": "fabric-0"}},
+		{"cidr": "fd00::/64", "vlan": {"name": "vlan20", "fabric": "fabric-1"}}
+	]`)
+
+	var raw []maasSubnet
+	err := json.Unmarshal(body, &raw)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshalling fixture: %v", err)
+	}
+
+	subnets := subnetsFromRaw(raw)
+
+	if len(subnets) != 2 {
+		t.Fatalf("expected 2 subnets, got %d", len(subnets))
+	}
+
+	if subnets[0].Family != "inet" {
+		t.Fatalf("expected IPv4 subnet to have family %q, got %q", "inet", subnets[0].Family)
+	}
+
+	if subnets[0].Fabric != "fabric-0" || subnets[0].VLAN != "untagged" {
+		t.Fatalf("unexpected fabric/VLAN for IPv4 subnet: %+v", subnets[0])
+	}
+
+	if subnets[1].Family != "inet6" {
+		t.Fatalf("expected IPv6 subnet to have family %q, got %q", "inet6", subnets[1].Family)
+	}
+}
+
+func TestOauthEscapeEncodesReservedCharacters(t *testing.T) {
+	escaped := oauthEscape(`sec"ret, with spaces`)
+	if escaped != `sec%22ret%2C%20with%20spaces` {
+		t.Fatalf("unexpected escaping: %q", escaped)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/client"
+	maasclient "github.com/lxc/lxd/lxd/maas/client"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+// cmdInit implements "lxd init", which walks the user through configuring a fresh LXD server and
+// applies the result.
+type cmdInit struct {
+	global *cmdGlobal
+
+	// flagNoDiscovery disables mDNS discovery of other LXD servers during the clustering
+	// questions, for headless preseed-driven setups where nothing should advertise itself or
+	// probe the network beyond what the user explicitly typed in.
+	flagNoDiscovery bool
+
+	// flagDryRun and flagFormat back "lxd init --dry-run": instead of applying the assembled
+	// config, validateConfig is run against it and the resulting report is printed in the
+	// requested format, so preseed YAMLs can be checked in CI before being rolled out.
+	flagDryRun bool
+	flagFormat string
+
+	// flagPreseed points at a preseed YAML file holding an already-assembled initData ("-" for
+	// stdin), bypassing RunInteractive entirely. This is what lets --dry-run be used from a CI
+	// pipeline: without it, "lxd init --dry-run" would just hang on the interactive wizard's tty
+	// prompts.
+	flagPreseed string
+}
+
+func (c *cmdInit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "init"
+	cmd.Short = i18n.G("Configure the LXD daemon")
+	cmd.Long = i18n.G(`Configure the LXD daemon`)
+	cmd.RunE = c.Run
+
+	cmd.Flags().BoolVar(&c.flagNoDiscovery, "no-discovery", false, i18n.G("Disable mDNS discovery of other LXD servers during clustering questions"))
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, i18n.G("Validate the configuration without applying it"))
+	cmd.Flags().StringVar(&c.flagFormat, "format", "yaml", i18n.G("Format for the --dry-run report (yaml or json)"))
+	cmd.Flags().StringVar(&c.flagPreseed, "preseed", "", i18n.G("Read a YAML preseed from the given file (or \"-\" for stdin) instead of asking questions interactively"))
+
+	return cmd
+}
+
+func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
+	if c.flagDryRun && c.flagFormat != "yaml" && c.flagFormat != "json" {
+		return fmt.Errorf(i18n.G("Invalid --format %q, must be yaml or json"), c.flagFormat)
+	}
+
+	d, err := lxd.ConnectLXDUnix("", nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to connect to local LXD")
+	}
+
+	var config *initData
+	if c.flagPreseed != "" {
+		config, err = c.readPreseed()
+	} else {
+		config, err = c.RunInteractive(cmd, args, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.flagDryRun {
+		return c.runDryRun(d, config)
+	}
+
+	return c.apply(d, config)
+}
+
+// readPreseed loads an initData from the YAML pointed at by --preseed, without asking any
+// interactive questions. This is what lets --dry-run (and "lxd init" itself) run non-interactively
+// in a CI pipeline.
+func (c *cmdInit) readPreseed() (*initData, error) {
+	var in []byte
+	var err error
+	if c.flagPreseed == "-" {
+		in, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		in, err = ioutil.ReadFile(c.flagPreseed)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read the preseed YAML")
+	}
+
+	config := &initData{}
+	err = yaml.Unmarshal(in, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse the preseed YAML")
+	}
+
+	return config, nil
+}
+
+// runDryRun validates an assembled config against the target server without applying it, prints
+// the resulting report in the requested format, and returns an error (causing a non-zero exit
+// code) if any check failed.
+func (c *cmdInit) runDryRun(d lxd.ContainerServer, config *initData) error {
+	report := c.validateConfig(d, config)
+
+	var out []byte
+	var err error
+	if c.flagFormat == "json" {
+		out, err = json.MarshalIndent(report, "", "\t")
+	} else {
+		out, err = yaml.Marshal(report)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to render the validation report")
+	}
+
+	fmt.Printf("%s\n", out)
+
+	if !report.Success {
+		return fmt.Errorf(i18n.G("Configuration failed validation"))
+	}
+
+	return nil
+}
+
+// apply pushes an assembled initData to the target server: the cluster join first (so that the
+// storage pools and networks below land as this member's half of configs the target already
+// defined), then server-level config, then storage pools and networks, since profiles may
+// reference either of them as devices.
+func (c *cmdInit) apply(d lxd.ContainerServer, config *initData) error {
+	if config.Cluster != nil {
+		op, err := d.UpdateCluster(api.ClusterPut{
+			ServerName:         config.Cluster.ServerName,
+			Enabled:            config.Cluster.Enabled,
+			ClusterAddress:     config.Cluster.ClusterAddress,
+			ClusterCertificate: config.Cluster.ClusterCertificate,
+			ClusterToken:       config.Cluster.ClusterToken,
+			ClusterPassword:    config.Cluster.ClusterPassword,
+		}, "")
+		if err != nil {
+			return errors.Wrap(err, "Failed to join the cluster")
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return errors.Wrap(err, "Failed to join the cluster")
+		}
+	}
+
+	if len(config.Config) > 0 {
+		server, etag, err := d.GetServer()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve server config")
+		}
+
+		writable := server.Writable()
+		for key, value := range config.Config {
+			writable.Config[key] = value
+		}
+
+		err = d.UpdateServer(writable, etag)
+		if err != nil {
+			return errors.Wrap(err, "Failed to update server configuration")
+		}
+	}
+
+	for _, pool := range config.StoragePools {
+		err := d.CreateStoragePool(pool)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create storage pool %q", pool.Name)
+		}
+	}
+
+	for _, network := range config.Networks {
+		err := d.CreateNetwork(network)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create network %q", network.Name)
+		}
+	}
+
+	for _, profile := range config.Profiles {
+		_, _, err := d.GetProfile(profile.Name)
+		if err != nil {
+			err = d.CreateProfile(profile)
+		} else {
+			err = d.UpdateProfile(profile.Name, profile.ProfilePut, "")
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "Failed to configure profile %q", profile.Name)
+		}
+	}
+
+	return nil
+}
+
+// initData holds the full configuration assembled by "lxd init" before it's applied to the
+// target server.
+type initData struct {
+	Config       map[string]interface{} `yaml:"config"`
+	Networks     []api.NetworksPost     `yaml:"networks"`
+	StoragePools []api.StoragePoolsPost `yaml:"storage_pools"`
+	Profiles     []api.ProfilesPost     `yaml:"profiles"`
+	Cluster      *initDataCluster       `yaml:"cluster,omitempty"`
+
+	// MAASSubnets is populated by askMAAS from the target MAAS server's API, so that
+	// askNetworking/askMAASSubnet can offer them as a menu instead of asking the user to type
+	// a subnet name from memory. It isn't part of the preseed format and is never marshalled.
+	MAASSubnets []maasclient.Subnet `yaml:"-"`
+}
+
+// initDataCluster holds the clustering-specific part of initData.
+type initDataCluster struct {
+	Enabled            bool   `yaml:"enabled"`
+	ServerName         string `yaml:"server_name,omitempty"`
+	ClusterAddress     string `yaml:"cluster_address,omitempty"`
+	ClusterCertificate string `yaml:"cluster_certificate,omitempty"`
+	ClusterPassword    string `yaml:"cluster_password,omitempty"`
+
+	// ClusterToken is the join token the user pasted in, if any, in lieu of typing in the
+	// cluster address/fingerprint and trust password by hand. askClustering derives
+	// ClusterAddress/ClusterCertificate/ClusterPassword from it, and apply also sends it as-is
+	// in the join request (api.ClusterPut.ClusterToken).
+	ClusterToken string `yaml:"cluster_token,omitempty"`
+}
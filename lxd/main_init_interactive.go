@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -15,14 +19,25 @@ import (
 
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/cluster/discovery"
+	maasclient "github.com/lxc/lxd/lxd/maas/client"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
-	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/idmap"
 )
 
+// discoveryBrowseTimeout is how long we listen for mDNS replies when browsing for other LXD
+// servers on the LAN while asking the clustering questions.
+const discoveryBrowseTimeout = 5 * time.Second
+
 func (c *cmdInit) RunInteractive(cmd *cobra.Command, args []string, d lxd.ContainerServer) (*initData, error) {
+	return c.runInteractive(ttyQuestioner{}, cmd, args, d)
+}
+
+// runInteractive takes a Questioner rather than always driving the tty, so that it can be
+// exercised with a ScriptedQuestioner by both external orchestrators and unit tests.
+func (c *cmdInit) runInteractive(q Questioner, cmd *cobra.Command, args []string, d lxd.ContainerServer) (*initData, error) {
 	// Initialize config
 	config := initData{}
 	config.Config = map[string]interface{}{}
@@ -39,7 +54,7 @@ func (c *cmdInit) RunInteractive(cmd *cobra.Command, args []string, d lxd.Contai
 	}
 
 	// Clustering
-	err := c.askClustering(&config, d)
+	err := c.askClustering(q, &config, d)
 	if err != nil {
 		return nil, err
 	}
@@ -47,32 +62,32 @@ func (c *cmdInit) RunInteractive(cmd *cobra.Command, args []string, d lxd.Contai
 	// Ask all the other questions
 	if config.Cluster == nil || config.Cluster.ClusterAddress == "" {
 		// Storage
-		err = c.askStorage(&config, d)
+		err = c.askStorage(q, &config, d)
 		if err != nil {
 			return nil, err
 		}
 
 		// MAAS
-		err = c.askMAAS(&config, d)
+		err = c.askMAAS(q, &config, d)
 		if err != nil {
 			return nil, err
 		}
 
 		// Networking
-		err = c.askNetworking(&config, d)
+		err = c.askNetworking(q, &config, d)
 		if err != nil {
 			return nil, err
 		}
 
 		// Daemon config
-		err = c.askDaemon(&config, d)
+		err = c.askDaemon(q, &config, d)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	// Print the YAML
-	if cli.AskBool("Would you like a YAML \"lxd init\" preseed to be printed [default=no]? ", "no") {
+	if q.Bool("init.print_yaml", "Would you like a YAML \"lxd init\" preseed to be printed [default=no]? ", false) {
 		out, err := yaml.Marshal(config)
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to render the config")
@@ -84,8 +99,8 @@ func (c *cmdInit) RunInteractive(cmd *cobra.Command, args []string, d lxd.Contai
 	return &config, nil
 }
 
-func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
-	if cli.AskBool("Would you like to use LXD clustering? (yes/no) [default=no]: ", "no") {
+func (c *cmdInit) askClustering(q Questioner, config *initData, d lxd.ContainerServer) error {
+	if q.Bool("clustering.enabled", "Would you like to use LXD clustering? (yes/no) [default=no]: ", false) {
 		config.Cluster = &initDataCluster{}
 		config.Cluster.Enabled = true
 
@@ -95,20 +110,140 @@ func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
 			serverName = "lxd"
 		}
 
-		config.Cluster.ServerName = cli.AskString(
+		config.Cluster.ServerName = q.String("clustering.server_name",
 			fmt.Sprintf("What name should be used to identify this node in the cluster? [default=%s]: ", serverName), serverName, nil)
 
 		// Cluster server address
 		address := util.NetworkInterfaceAddress()
-		serverAddress := util.CanonicalNetworkAddress(cli.AskString(
+		serverAddress := util.CanonicalNetworkAddress(q.String("clustering.server_address",
 			fmt.Sprintf("What IP address or DNS name should be used to reach this node? [default=%s]: ", address), address, nil))
 		config.Config["core.https_address"] = serverAddress
 
-		if cli.AskBool("Are you joining an existing cluster? (yes/no) [default=no]: ", "no") {
+		// Advertise ourselves on the LAN so that other nodes running "lxd init" can find us,
+		// unless the user asked for a headless/preseed-driven setup.
+		if !c.flagNoDiscovery {
+			_, portString, err := net.SplitHostPort(serverAddress)
+			if err != nil {
+				portString = "8443"
+			}
+
+			port, err := strconv.Atoi(portString)
+			if err == nil {
+				fingerprint := c.localCertificateFingerprint()
+				server, err := discovery.Advertise(config.Cluster.ServerName, serverAddress, port, fingerprint)
+				if err == nil {
+					defer server.Shutdown()
+				}
+			}
+		}
+
+		if q.Bool("clustering.join", "Are you joining an existing cluster? (yes/no) [default=no]: ", false) {
+			// Browse for other LXD servers on the LAN so the user can pick one from a menu
+			// instead of having to type its address.
+			var discoveredAddress string
+			var discoveredFingerprint string
+			if !c.flagNoDiscovery {
+				ctx, cancel := context.WithTimeout(context.Background(), discoveryBrowseTimeout)
+				peers, err := discovery.Browse(ctx)
+				cancel()
+
+				// Exclude ourselves: we're advertising on the LAN for the whole duration of
+				// this prompt, so our own freshly-initialized node would otherwise show up
+				// in the menu.
+				localFingerprint := c.localCertificateFingerprint()
+				others := make([]discovery.Peer, 0, len(peers))
+				for _, peer := range peers {
+					if peer.Fingerprint == localFingerprint || peer.Address == serverAddress {
+						continue
+					}
+
+					others = append(others, peer)
+				}
+				peers = others
+
+				if err == nil && len(peers) > 0 {
+					manualEntry := "Enter address manually"
+					options := make([]string, 0, len(peers)+1)
+					for _, peer := range peers {
+						options = append(options, fmt.Sprintf("%s (%s, fingerprint: %s)", peer.ServerName, peer.Address, peer.Fingerprint))
+					}
+					options = append(options, manualEntry)
+
+					choice := q.Choice("clustering.join_discovered_peer", "Select the cluster node to join:\n", options, manualEntry)
+					for i, option := range options {
+						if option == choice && i < len(peers) {
+							discoveredAddress = peers[i].Address
+							discoveredFingerprint = peers[i].Fingerprint
+							break
+						}
+					}
+				}
+			}
+
 			// Existing cluster
 			for {
+				if discoveredAddress != "" {
+					config.Cluster.ClusterAddress = discoveredAddress
+
+					cert, err := shared.GetRemoteCertificate(fmt.Sprintf("https://%s", config.Cluster.ClusterAddress))
+					if err != nil {
+						fmt.Printf("Error connecting to existing cluster node: %v\n", err)
+						discoveredAddress = ""
+						continue
+					}
+
+					certDigest := shared.CertFingerprint(cert)
+					if certDigest != discoveredFingerprint {
+						fmt.Printf("Certificate fingerprint mismatch: discovery reported %q, node at %s presented %q\n", discoveredFingerprint, config.Cluster.ClusterAddress, certDigest)
+						discoveredAddress = ""
+						continue
+					}
+
+					config.Cluster.ClusterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+					config.Cluster.ClusterPassword = q.Password("clustering.join_password", "Cluster trust password: ")
+					break
+				}
+
+				// A join token lets us skip the address, fingerprint-confirmation and
+				// trust-password prompts entirely: everything we need is already in it.
+				joinToken := q.String("clustering.join_token", "Cluster join token (leave empty to enter the connection details manually): ", "", func(string) error { return nil })
+				if joinToken != "" {
+					token, err := api.DecodeClusterMemberJoinToken(joinToken)
+					if err != nil {
+						fmt.Printf("Invalid cluster join token: %v\n", err)
+						continue
+					}
+
+					config.Cluster.ClusterAddress = token.ClusterAddress
+
+					cert, err := shared.GetRemoteCertificate(fmt.Sprintf("https://%s", config.Cluster.ClusterAddress))
+					if err != nil {
+						fmt.Printf("Error connecting to existing cluster node: %v\n", err)
+						continue
+					}
+
+					certDigest := shared.CertFingerprint(cert)
+					if certDigest != token.Fingerprint {
+						fmt.Printf("Certificate fingerprint mismatch: join token has %q, node at %s presented %q\n", token.Fingerprint, config.Cluster.ClusterAddress, certDigest)
+						continue
+					}
+
+					// The token carries the server name the leader pre-registered via
+					// "lxc cluster add <name>"; that's the name the join has to target, so it
+					// overrides whatever was typed in answer to the server-name question above.
+					if token.ServerName != "" && token.ServerName != config.Cluster.ServerName {
+						fmt.Printf("Using server name %q from the join token instead of %q\n", token.ServerName, config.Cluster.ServerName)
+						config.Cluster.ServerName = token.ServerName
+					}
+
+					config.Cluster.ClusterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+					config.Cluster.ClusterToken = joinToken
+					config.Cluster.ClusterPassword = token.Secret
+					break
+				}
+
 				// Cluster URL
-				clusterAddress := cli.AskString("IP address or FQDN of an existing cluster node: ", "", nil)
+				clusterAddress := q.String("clustering.join_address", "IP address or FQDN of an existing cluster node: ", "", nil)
 				_, _, err := net.SplitHostPort(clusterAddress)
 				if err != nil {
 					clusterAddress = fmt.Sprintf("%s:8443", clusterAddress)
@@ -124,18 +259,18 @@ func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
 
 				certDigest := shared.CertFingerprint(cert)
 				fmt.Printf("Cluster certificate fingerprint: %s\n", certDigest)
-				if !cli.AskBool("ok? (yes/no) [default=no]: ", "no") {
+				if !q.Bool("clustering.join_fingerprint_ok", "ok? (yes/no) [default=no]: ", false) {
 					return fmt.Errorf("User aborted configuration")
 				}
 				config.Cluster.ClusterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
 
 				// Cluster password
-				config.Cluster.ClusterPassword = cli.AskPasswordOnce("Cluster trust password: ")
+				config.Cluster.ClusterPassword = q.Password("clustering.join_password", "Cluster trust password: ")
 				break
 			}
 
 			// Confirm wiping
-			if !cli.AskBool("All existing data is lost when joining a cluster, continue? (yes/no) [default=no] ", "no") {
+			if !q.Bool("clustering.join_wipe_confirm", "All existing data is lost when joining a cluster, continue? (yes/no) [default=no] ", false) {
 				return fmt.Errorf("User aborted configuration")
 			}
 
@@ -193,7 +328,7 @@ func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
 				if pool.Config["source"] != "" {
 					// Dummy validator for allowing empty strings
 					validator := func(string) error { return nil }
-					newPool.Config["source"] = cli.AskString(
+					newPool.Config["source"] = q.String(fmt.Sprintf("clustering.storage_pool_source.%s", pool.Name),
 						fmt.Sprintf(`Choose the local disk or dataset for storage pool "%s" (empty for loop disk): `, pool.Name), "", validator)
 				}
 
@@ -225,7 +360,7 @@ func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
 				if network.Config["bridge.external_interfaces"] != "" {
 					// Dummy validator for allowing empty strings
 					validator := func(string) error { return nil }
-					newNetwork.Config["bridge.external_interfaces"] = cli.AskString(
+					newNetwork.Config["bridge.external_interfaces"] = q.String(fmt.Sprintf("clustering.network_interface.%s", network.Name),
 						fmt.Sprintf(`Choose the local network interface to connect to network "%s" (empty for none): `, network.Name), "", validator)
 				}
 
@@ -233,8 +368,8 @@ func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
 			}
 		} else {
 			// Password authentication
-			if cli.AskBool("Setup password authentication on the cluster? (yes/no) [default=yes]: ", "yes") {
-				config.Config["core.trust_password"] = cli.AskPassword("Trust password for new clients: ")
+			if q.Bool("clustering.trust_password_enabled", "Setup password authentication on the cluster? (yes/no) [default=yes]: ", true) {
+				config.Config["core.trust_password"] = q.PasswordConfirm("clustering.trust_password", "Trust password for new clients: ")
 			}
 		}
 	}
@@ -242,8 +377,8 @@ func (c *cmdInit) askClustering(config *initData, d lxd.ContainerServer) error {
 	return nil
 }
 
-func (c *cmdInit) askMAAS(config *initData, d lxd.ContainerServer) error {
-	if !cli.AskBool("Would you like to connect to a MAAS server (yes/no) [default=no]? ", "no") {
+func (c *cmdInit) askMAAS(q Questioner, config *initData, d lxd.ContainerServer) error {
+	if !q.Bool("maas.enabled", "Would you like to connect to a MAAS server (yes/no) [default=no]? ", false) {
 		return nil
 	}
 
@@ -252,22 +387,61 @@ func (c *cmdInit) askMAAS(config *initData, d lxd.ContainerServer) error {
 		serverName = "lxd"
 	}
 
-	maasHostname := cli.AskString(fmt.Sprintf("What's the name of this host in MAAS? [default=%s]? ", serverName), serverName, nil)
+	maasHostname := q.String("maas.hostname", fmt.Sprintf("What's the name of this host in MAAS? [default=%s]? ", serverName), serverName, nil)
 	if maasHostname != serverName {
 		config.Config["maas.machine"] = maasHostname
 	}
 
-	config.Config["maas.api.url"] = cli.AskString("What's the URL of your MAAS server? ", "", nil)
-	config.Config["maas.api.key"] = cli.AskString("What's a valid API key for your MAAS server? ", "", nil)
+	maasURL := q.String("maas.api_url", "What's the URL of your MAAS server? ", "", nil)
+	maasKey := q.String("maas.api_key", "What's a valid API key for your MAAS server? ", "", nil)
+	config.Config["maas.api.url"] = maasURL
+	config.Config["maas.api.key"] = maasKey
+
+	// Cache the subnets known to MAAS so askNetworking can offer them as a menu instead of
+	// asking the user to type a subnet name from memory.
+	subnets, err := maasclient.New(maasURL, maasKey).ListSubnets(context.Background())
+	if err != nil {
+		fmt.Printf("Couldn't query MAAS for its subnets, you'll be asked to type them in manually: %v\n", err)
+		return nil
+	}
+
+	config.MAASSubnets = subnets
 
 	return nil
 }
 
-func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
-	if !cli.AskBool("Would you like to create a new network bridge (yes/no) [default=yes]? ", "yes") {
-		if cli.AskBool("Would you like to configure LXD to use an existing bridge or host interface (yes/no) [default=no]? ", "no") {
+// askMAASSubnet asks the user to pick a MAAS subnet of the given address family for a network
+// interface. It presents a choice menu built from the subnets of that family cached by askMAAS,
+// falling back to the original free-form prompt if none of that family were cached (e.g. because
+// the API query failed).
+func (c *cmdInit) askMAASSubnet(q Questioner, config *initData, id string, family string, label string) string {
+	options := []string{"none"}
+	for _, subnet := range config.MAASSubnets {
+		if subnet.Family != family {
+			continue
+		}
+
+		options = append(options, fmt.Sprintf("%s (VLAN %s)", subnet.CIDR, subnet.VLAN))
+	}
+
+	if len(options) == 1 {
+		return q.String(id, fmt.Sprintf("What's the name of the MAAS %s subnet for this interface (empty for no subnet)? ", label), "",
+			func(input string) error { return nil })
+	}
+
+	choice := q.Choice(id, fmt.Sprintf("Which MAAS %s subnet should this interface use?\n", label), options, "none")
+	if choice == "none" {
+		return ""
+	}
+
+	return strings.SplitN(choice, " ", 2)[0]
+}
+
+func (c *cmdInit) askNetworking(q Questioner, config *initData, d lxd.ContainerServer) error {
+	if !q.Bool("network.create_bridge", "Would you like to create a new network bridge (yes/no) [default=yes]? ", true) {
+		if q.Bool("network.use_existing", "Would you like to configure LXD to use an existing bridge or host interface (yes/no) [default=no]? ", false) {
 			for {
-				name := cli.AskString("Name of the existing bridge or host interface: ", "", nil)
+				name := q.String("network.existing_name", "Name of the existing bridge or host interface: ", "", nil)
 
 				if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", name)) {
 					fmt.Println("The requested interface doesn't exist. Please choose another one.")
@@ -286,17 +460,13 @@ func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
 					config.Profiles[0].Devices["eth0"]["nictype"] = "bridged"
 				}
 
-				if config.Config["maas.api.url"] != "" && cli.AskBool("Is this interface connected to your MAAS server? (yes/no) [default=yes]? ", "yes") {
-					maasSubnetV4 := cli.AskString("What's the name of the MAAS IPv4 subnet for this interface (empty for no subnet)? ", "",
-						func(input string) error { return nil })
-
+				if config.Config["maas.api.url"] != "" && q.Bool("network.maas_connected", "Is this interface connected to your MAAS server? (yes/no) [default=yes]? ", true) {
+					maasSubnetV4 := c.askMAASSubnet(q, config, "network.maas_subnet_v4", "inet", "IPv4")
 					if maasSubnetV4 != "" {
 						config.Profiles[0].Devices["eth0"]["maas.subnet.ipv4"] = maasSubnetV4
 					}
 
-					maasSubnetV6 := cli.AskString("What's the name of the MAAS IPv6 subnet for this interface (empty for no subnet)? ", "",
-						func(input string) error { return nil })
-
+					maasSubnetV6 := c.askMAASSubnet(q, config, "network.maas_subnet_v6", "inet6", "IPv6")
 					if maasSubnetV6 != "" {
 						config.Profiles[0].Devices["eth0"]["maas.subnet.ipv6"] = maasSubnetV6
 					}
@@ -315,7 +485,7 @@ func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
 		network.Config = map[string]string{}
 
 		// Network name
-		network.Name = cli.AskString("What should the new bridge be called [default=lxdbr0]? ", "lxdbr0", networkValidName)
+		network.Name = q.String("network.bridge_name", "What should the new bridge be called [default=lxdbr0]? ", "lxdbr0", networkValidName)
 		_, _, err := d.GetNetwork(network.Name)
 		if err == nil {
 			fmt.Printf("The requested network bridge \"%s\" already exists. Please choose another name.\n", network.Name)
@@ -331,7 +501,7 @@ func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
 		}
 
 		// IPv4
-		network.Config["ipv4.address"] = cli.AskString("What IPv4 address should be used (CIDR subnet notation, “auto” or “none”) [default=auto]? ", "auto", func(value string) error {
+		network.Config["ipv4.address"] = q.String("network.ipv4_address", "What IPv4 address should be used (CIDR subnet notation, “auto” or “none”) [default=auto]? ", "auto", func(value string) error {
 			if shared.StringInSlice(value, []string{"auto", "none"}) {
 				return nil
 			}
@@ -341,11 +511,11 @@ func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
 
 		if !shared.StringInSlice(network.Config["ipv4.address"], []string{"auto", "none"}) {
 			network.Config["ipv4.nat"] = fmt.Sprintf("%v",
-				cli.AskBool("Would you like LXD to NAT IPv4 traffic on your bridge? [default=yes]? ", "yes"))
+				q.Bool("network.ipv4_nat", "Would you like LXD to NAT IPv4 traffic on your bridge? [default=yes]? ", true))
 		}
 
 		// IPv6
-		network.Config["ipv6.address"] = cli.AskString("What IPv6 address should be used (CIDR subnet notation, “auto” or “none”) [default=auto]? ", "auto", func(value string) error {
+		network.Config["ipv6.address"] = q.String("network.ipv6_address", "What IPv6 address should be used (CIDR subnet notation, “auto” or “none”) [default=auto]? ", "auto", func(value string) error {
 			if shared.StringInSlice(value, []string{"auto", "none"}) {
 				return nil
 			}
@@ -355,7 +525,7 @@ func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
 
 		if !shared.StringInSlice(network.Config["ipv6.address"], []string{"auto", "none"}) {
 			network.Config["ipv6.nat"] = fmt.Sprintf("%v",
-				cli.AskBool("Would you like LXD to NAT IPv6 traffic on your bridge? [default=yes]? ", "yes"))
+				q.Bool("network.ipv6_nat", "Would you like LXD to NAT IPv6 traffic on your bridge? [default=yes]? ", true))
 		}
 
 		// Add the new network
@@ -366,17 +536,17 @@ func (c *cmdInit) askNetworking(config *initData, d lxd.ContainerServer) error {
 	return nil
 }
 
-func (c *cmdInit) askStorage(config *initData, d lxd.ContainerServer) error {
+func (c *cmdInit) askStorage(q Questioner, config *initData, d lxd.ContainerServer) error {
 	if config.Cluster != nil {
-		if cli.AskBool("Do you want to configure a new local storage pool (yes/no) [default=yes]? ", "yes") {
-			err := c.askStoragePool(config, d, "local")
+		if q.Bool("storage.local_pool_enabled", "Do you want to configure a new local storage pool (yes/no) [default=yes]? ", true) {
+			err := c.askStoragePool(q, config, d, "local")
 			if err != nil {
 				return err
 			}
 		}
 
-		if cli.AskBool("Do you want to configure a new remote storage pool (yes/no) [default=yes]? ", "yes") {
-			err := c.askStoragePool(config, d, "remote")
+		if q.Bool("storage.remote_pool_enabled", "Do you want to configure a new remote storage pool (yes/no) [default=yes]? ", true) {
+			err := c.askStoragePool(q, config, d, "remote")
 			if err != nil {
 				return err
 			}
@@ -385,14 +555,14 @@ func (c *cmdInit) askStorage(config *initData, d lxd.ContainerServer) error {
 		return nil
 	}
 
-	if !cli.AskBool("Do you want to configure a new storage pool (yes/no) [default=yes]? ", "yes") {
+	if !q.Bool("storage.pool_enabled", "Do you want to configure a new storage pool (yes/no) [default=yes]? ", true) {
 		return nil
 	}
 
-	return c.askStoragePool(config, d, "all")
+	return c.askStoragePool(q, config, d, "all")
 }
 
-func (c *cmdInit) askStoragePool(config *initData, d lxd.ContainerServer, poolType string) error {
+func (c *cmdInit) askStoragePool(q Questioner, config *initData, d lxd.ContainerServer, poolType string) error {
 	// Figure out the preferred storage driver
 	availableBackends := c.availableStorageDrivers(poolType)
 
@@ -420,7 +590,7 @@ func (c *cmdInit) askStoragePool(config *initData, d lxd.ContainerServer, poolTy
 		pool.Config = map[string]string{}
 
 		if poolType == "all" {
-			pool.Name = cli.AskString("Name of the new storage pool [default=default]: ", "default", nil)
+			pool.Name = q.String("storage.pool_name", "Name of the new storage pool [default=default]: ", "default", nil)
 		} else {
 			pool.Name = poolType
 		}
@@ -444,7 +614,7 @@ func (c *cmdInit) askStoragePool(config *initData, d lxd.ContainerServer, poolTy
 
 		// Storage backend
 		if len(availableBackends) > 1 {
-			pool.Driver = cli.AskChoice(
+			pool.Driver = q.Choice(fmt.Sprintf("storage.%s.driver", poolType),
 				fmt.Sprintf("Name of the storage backend to use (%s) [default=%s]: ", strings.Join(availableBackends, ", "), defaultStorage), availableBackends, defaultStorage)
 		} else {
 			pool.Driver = availableBackends[0]
@@ -458,24 +628,24 @@ func (c *cmdInit) askStoragePool(config *initData, d lxd.ContainerServer, poolTy
 
 		// Optimization for btrfs on btrfs
 		if pool.Driver == "btrfs" && backingFs == "btrfs" {
-			if cli.AskBool(fmt.Sprintf("Would you like to create a new btrfs subvolume under %s (yes/no) [default=yes]: ", shared.VarPath("")), "yes") {
+			if q.Bool(fmt.Sprintf("storage.%s.btrfs_subvolume", poolType), fmt.Sprintf("Would you like to create a new btrfs subvolume under %s (yes/no) [default=yes]: ", shared.VarPath("")), true) {
 				pool.Config["source"] = shared.VarPath("storage-pools", pool.Name)
 				config.StoragePools = append(config.StoragePools, pool)
 				break
 			}
 		}
 
-		if cli.AskBool(fmt.Sprintf("Create a new %s pool (yes/no) [default=yes]? ", strings.ToUpper(pool.Driver)), "yes") {
+		if q.Bool(fmt.Sprintf("storage.%s.create_new", poolType), fmt.Sprintf("Create a new %s pool (yes/no) [default=yes]? ", strings.ToUpper(pool.Driver)), true) {
 			if pool.Driver == "ceph" {
 				// Ask for the name of the cluster
-				pool.Config["ceph.cluster_name"] = cli.AskString("Name of the existing CEPH cluster [default=ceph]: ", "ceph", nil)
+				pool.Config["ceph.cluster_name"] = q.String(fmt.Sprintf("storage.%s.ceph_cluster_name", poolType), "Name of the existing CEPH cluster [default=ceph]: ", "ceph", nil)
 
 				// Ask for the name of the osd pool
-				pool.Config["ceph.osd.pool_name"] = cli.AskString("Name of the OSD storage pool [default=lxd]: ", "lxd", nil)
+				pool.Config["ceph.osd.pool_name"] = q.String(fmt.Sprintf("storage.%s.ceph_osd_pool_name", poolType), "Name of the OSD storage pool [default=lxd]: ", "lxd", nil)
 
 				// Ask for the number of placement groups
-				pool.Config["ceph.osd.pg_num"] = cli.AskString("Number of placement groups [default=32]: ", "32", nil)
-			} else if cli.AskBool("Would you like to use an existing block device (yes/no) [default=no]? ", "no") {
+				pool.Config["ceph.osd.pg_num"] = q.String(fmt.Sprintf("storage.%s.ceph_osd_pg_num", poolType), "Number of placement groups [default=32]: ", "32", nil)
+			} else if q.Bool(fmt.Sprintf("storage.%s.use_existing_block", poolType), "Would you like to use an existing block device (yes/no) [default=no]? ", false) {
 				deviceExists := func(path string) error {
 					if !shared.IsBlockdevPath(path) {
 						return fmt.Errorf("'%s' is not a block device", path)
@@ -484,7 +654,7 @@ func (c *cmdInit) askStoragePool(config *initData, d lxd.ContainerServer, poolTy
 					return nil
 				}
 
-				pool.Config["source"] = cli.AskString("Path to the existing block device: ", "", deviceExists)
+				pool.Config["source"] = q.String(fmt.Sprintf("storage.%s.block_device_path", poolType), "Path to the existing block device: ", "", deviceExists)
 			} else {
 				st := syscall.Statfs_t{}
 				err := syscall.Statfs(shared.VarPath(), &st)
@@ -501,20 +671,20 @@ func (c *cmdInit) askStoragePool(config *initData, d lxd.ContainerServer, poolTy
 					defaultSize = 15
 				}
 
-				pool.Config["size"] = fmt.Sprintf("%dGB", cli.AskInt(
+				pool.Config["size"] = fmt.Sprintf("%dGB", q.Int(fmt.Sprintf("storage.%s.loop_size", poolType),
 					fmt.Sprintf("Size in GB of the new loop device (1GB minimum) [default=%dGB]: ", defaultSize), 1, -1, fmt.Sprintf("%d", defaultSize)))
 			}
 		} else {
 			if pool.Driver == "ceph" {
 				// ask for the name of the cluster
-				pool.Config["ceph.cluster_name"] = cli.AskString("Name of the existing CEPH cluster [default=ceph]: ", "ceph", nil)
+				pool.Config["ceph.cluster_name"] = q.String(fmt.Sprintf("storage.%s.ceph_cluster_name", poolType), "Name of the existing CEPH cluster [default=ceph]: ", "ceph", nil)
 
 				// ask for the name of the existing pool
-				pool.Config["source"] = cli.AskString("Name of the existing OSD storage pool [default=lxd]: ", "lxd", nil)
+				pool.Config["source"] = q.String(fmt.Sprintf("storage.%s.ceph_osd_pool_name", poolType), "Name of the existing OSD storage pool [default=lxd]: ", "lxd", nil)
 				pool.Config["ceph.osd.pool_name"] = pool.Config["source"]
 			} else {
 				question := fmt.Sprintf("Name of the existing %s pool or dataset: ", strings.ToUpper(pool.Driver))
-				pool.Config["source"] = cli.AskString(question, "", nil)
+				pool.Config["source"] = q.String(fmt.Sprintf("storage.%s.existing_source", poolType), question, "", nil)
 			}
 		}
 
@@ -531,7 +701,7 @@ If you wish to use thin provisioning, abort now, install the tools from
 your Linux distribution and run "lxd init" again afterwards.
 
 `)
-				if !cli.AskBool("Do you want to continue without thin provisioning? (yes/no) [default=yes]: ", "yes") {
+				if !q.Bool(fmt.Sprintf("storage.%s.lvm_thinpool_missing_continue", poolType), "Do you want to continue without thin provisioning? (yes/no) [default=yes]: ", true) {
 					return fmt.Errorf("The LVM thin provisioning tools couldn't be found on the system")
 				}
 
@@ -546,7 +716,7 @@ your Linux distribution and run "lxd init" again afterwards.
 	return nil
 }
 
-func (c *cmdInit) askDaemon(config *initData, d lxd.ContainerServer) error {
+func (c *cmdInit) askDaemon(q Questioner, config *initData, d lxd.ContainerServer) error {
 	// Detect lack of uid/gid
 	idmapset, err := idmap.DefaultIdmapSet("")
 	if (err != nil || len(idmapset.Idmap) == 0 || idmapset.Usable() != nil) && shared.RunningInUserNS() {
@@ -562,13 +732,13 @@ they otherwise would.
 
 `)
 
-		if cli.AskBool("Would you like to have your containers share their parent's allocation (yes/no) [default=yes]? ", "yes") {
+		if q.Bool("daemon.privileged_containers", "Would you like to have your containers share their parent's allocation (yes/no) [default=yes]? ", true) {
 			config.Profiles[0].Config["security.privileged"] = "true"
 		}
 	}
 
 	// Network listener
-	if config.Cluster == nil && cli.AskBool("Would you like LXD to be available over the network (yes/no) [default=no]? ", "no") {
+	if config.Cluster == nil && q.Bool("daemon.network_listener", "Would you like LXD to be available over the network (yes/no) [default=no]? ", false) {
 		isIPAddress := func(s string) error {
 			if s != "all" && net.ParseIP(s) == nil {
 				return fmt.Errorf("'%s' is not an IP address", s)
@@ -577,7 +747,7 @@ they otherwise would.
 			return nil
 		}
 
-		netAddr := cli.AskString("Address to bind LXD to (not including port) [default=all]: ", "all", isIPAddress)
+		netAddr := q.String("daemon.network_address", "Address to bind LXD to (not including port) [default=all]: ", "all", isIPAddress)
 		if netAddr == "all" {
 			netAddr = "::"
 		}
@@ -586,15 +756,37 @@ they otherwise would.
 			netAddr = fmt.Sprintf("[%s]", netAddr)
 		}
 
-		netPort := cli.AskInt("Port to bind LXD to [default=8443]: ", 1, 65535, "8443")
+		netPort := q.Int("daemon.network_port", "Port to bind LXD to [default=8443]: ", 1, 65535, "8443")
 		config.Config["core.https_address"] = fmt.Sprintf("%s:%d", netAddr, netPort)
-		config.Config["core.trust_password"] = cli.AskPassword("Trust password for new clients: ")
+		config.Config["core.trust_password"] = q.PasswordConfirm("daemon.trust_password", "Trust password for new clients: ")
 	}
 
 	// Ask if the user wants images to be automatically refreshed.
-	if !cli.AskBool("Would you like stale cached images to be updated automatically (yes/no) [default=yes]? ", "yes") {
+	if !q.Bool("daemon.auto_update_images", "Would you like stale cached images to be updated automatically (yes/no) [default=yes]? ", true) {
 		config.Config["images.auto_update_interval"] = "0"
 	}
 
 	return nil
 }
+
+// localCertificateFingerprint returns the fingerprint of this server's own certificate, for use
+// in the TXT record advertised over mDNS. An empty string is returned if the certificate can't be
+// loaded, in which case peers will simply not have a fingerprint to pre-validate against.
+func (c *cmdInit) localCertificateFingerprint() string {
+	certInfo, err := util.LoadCert(shared.VarPath(""))
+	if err != nil {
+		return ""
+	}
+
+	block, _ := pem.Decode(certInfo.PublicKey())
+	if block == nil {
+		return ""
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ""
+	}
+
+	return shared.CertFingerprint(cert)
+}
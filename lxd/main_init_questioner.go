@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/shared"
+	cli "github.com/lxc/lxd/shared/cmd"
+)
+
+// Questioner is the interface through which every "lxd init" ask* function obtains user input.
+// Splitting it out of the ask* functions means the exact same question logic can be driven either
+// by a real tty (ttyQuestioner) or by a fixed set of answers (ScriptedQuestioner), which is what
+// lets external tools such as MicroCloud drive "lxd init" end-to-end without scraping a pty, and
+// lets each ask* function be unit tested in isolation.
+//
+// id must be a short, stable identifier for the question (e.g. "clustering.server_name") that
+// doesn't change between releases: it's the key external callers and tests use to supply answers.
+type Questioner interface {
+	// Bool asks a yes/no question.
+	Bool(id string, question string, defaultAnswer bool) bool
+
+	// String asks a free-form question, validated with validator if it's not nil.
+	String(id string, question string, defaultAnswer string, validator func(string) error) string
+
+	// Password asks for a secret value that's already known to the user, e.g. the trust
+	// password of a cluster being joined, so it's only entered once.
+	Password(id string, question string) string
+
+	// PasswordConfirm asks for a brand-new secret value, e.g. a trust password being set up
+	// for the first time, so it's entered twice and rejected if the two entries don't match.
+	PasswordConfirm(id string, question string) string
+
+	// Choice asks the user to pick one of options, which must include defaultAnswer.
+	Choice(id string, question string, options []string, defaultAnswer string) string
+
+	// Int asks for an integer between min and max (inclusive); a negative max means there's no
+	// upper bound.
+	Int(id string, question string, min int64, max int64, defaultAnswer string) int64
+}
+
+// ttyQuestioner is the default Questioner, backed by the existing tty-based cli.Ask* helpers. It
+// ignores the question id since there's only ever one question on screen at a time.
+type ttyQuestioner struct{}
+
+func (ttyQuestioner) Bool(id string, question string, defaultAnswer bool) bool {
+	def := "no"
+	if defaultAnswer {
+		def = "yes"
+	}
+
+	return cli.AskBool(question, def)
+}
+
+func (ttyQuestioner) String(id string, question string, defaultAnswer string, validator func(string) error) string {
+	return cli.AskString(question, defaultAnswer, validator)
+}
+
+func (ttyQuestioner) Password(id string, question string) string {
+	return cli.AskPasswordOnce(question)
+}
+
+func (ttyQuestioner) PasswordConfirm(id string, question string) string {
+	return cli.AskPassword(question)
+}
+
+func (ttyQuestioner) Choice(id string, question string, options []string, defaultAnswer string) string {
+	return cli.AskChoice(question, options, defaultAnswer)
+}
+
+func (ttyQuestioner) Int(id string, question string, min int64, max int64, defaultAnswer string) int64 {
+	return cli.AskInt(question, min, max, defaultAnswer)
+}
+
+// ScriptedQuestioner answers every question from a fixed map, keyed by the same stable ids
+// documented on each ask* call site, instead of prompting a terminal.
+//
+// A missing or malformed answer is treated as a bug in the caller rather than something to fall
+// back on a tty for, so every method panics in that case instead of returning a zero value.
+type ScriptedQuestioner struct {
+	Answers map[string]interface{}
+}
+
+func (s *ScriptedQuestioner) lookup(id string) interface{} {
+	value, ok := s.Answers[id]
+	if !ok {
+		panic(fmt.Sprintf("lxd init: no scripted answer provided for question %q", id))
+	}
+
+	return value
+}
+
+func (s *ScriptedQuestioner) Bool(id string, question string, defaultAnswer bool) bool {
+	value, ok := s.lookup(id).(bool)
+	if !ok {
+		panic(fmt.Sprintf("lxd init: scripted answer for question %q is not a bool", id))
+	}
+
+	return value
+}
+
+func (s *ScriptedQuestioner) String(id string, question string, defaultAnswer string, validator func(string) error) string {
+	value, ok := s.lookup(id).(string)
+	if !ok {
+		panic(fmt.Sprintf("lxd init: scripted answer for question %q is not a string", id))
+	}
+
+	if validator != nil {
+		err := validator(value)
+		if err != nil {
+			panic(fmt.Sprintf("lxd init: scripted answer for question %q is invalid: %v", id, err))
+		}
+	}
+
+	return value
+}
+
+func (s *ScriptedQuestioner) Password(id string, question string) string {
+	value, ok := s.lookup(id).(string)
+	if !ok {
+		panic(fmt.Sprintf("lxd init: scripted answer for question %q is not a string", id))
+	}
+
+	return value
+}
+
+// PasswordConfirm answers from the same scripted map as Password: there's no second entry to
+// mismatch when the answer isn't coming from a tty.
+func (s *ScriptedQuestioner) PasswordConfirm(id string, question string) string {
+	return s.Password(id, question)
+}
+
+func (s *ScriptedQuestioner) Choice(id string, question string, options []string, defaultAnswer string) string {
+	value, ok := s.lookup(id).(string)
+	if !ok {
+		panic(fmt.Sprintf("lxd init: scripted answer for question %q is not a string", id))
+	}
+
+	if !shared.StringInSlice(value, options) {
+		panic(fmt.Sprintf("lxd init: scripted answer %q for question %q is not one of %v", value, id, options))
+	}
+
+	return value
+}
+
+func (s *ScriptedQuestioner) Int(id string, question string, min int64, max int64, defaultAnswer string) int64 {
+	var value int64
+
+	switch v := s.lookup(id).(type) {
+	case int64:
+		value = v
+	case int:
+		value = int64(v)
+	case float64:
+		// Answers decoded from JSON/YAML represent numbers as float64.
+		value = int64(v)
+	default:
+		panic(fmt.Sprintf("lxd init: scripted answer for question %q is not a number", id))
+	}
+
+	if value < min || (max >= 0 && value > max) {
+		panic(fmt.Sprintf("lxd init: scripted answer %d for question %q is out of range [%d, %d]", value, id, min, max))
+	}
+
+	return value
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScriptedQuestionerBool(t *testing.T) {
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"clustering.enabled": true}}
+
+	if !q.Bool("clustering.enabled", "", false) {
+		t.Fatal("expected scripted answer to be true")
+	}
+}
+
+func TestScriptedQuestionerBoolMissingPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected missing scripted answer to panic")
+		}
+	}()
+
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{}}
+	q.Bool("clustering.enabled", "", false)
+}
+
+func TestScriptedQuestionerString(t *testing.T) {
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"clustering.server_name": "node1"}}
+
+	value := q.String("clustering.server_name", "", "lxd", nil)
+	if value != "node1" {
+		t.Fatalf("expected %q, got %q", "node1", value)
+	}
+}
+
+func TestScriptedQuestionerStringValidatorRejects(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a failing validator to panic")
+		}
+	}()
+
+	alwaysInvalid := func(string) error { return fmt.Errorf("always invalid") }
+
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"network.bridge_name": "lxdbr0"}}
+	q.String("network.bridge_name", "", "lxdbr0", alwaysInvalid)
+}
+
+func TestScriptedQuestionerChoiceRejectsUnknownOption(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected an out-of-range choice to panic")
+		}
+	}()
+
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"storage.all.driver": "nope"}}
+	q.Choice("storage.all.driver", "", []string{"dir", "zfs"}, "dir")
+}
+
+func TestScriptedQuestionerIntRange(t *testing.T) {
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"storage.all.loop_size": float64(20)}}
+
+	value := q.Int("storage.all.loop_size", "", 1, 100, "15")
+	if value != 20 {
+		t.Fatalf("expected 20, got %d", value)
+	}
+}
+
+func TestScriptedQuestionerIntOutOfRangePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected an out-of-range int to panic")
+		}
+	}()
+
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"storage.all.loop_size": 200}}
+	q.Int("storage.all.loop_size", "", 1, 100, "15")
+}
+
+func TestScriptedQuestionerPasswordConfirmDoesNotAskTwice(t *testing.T) {
+	q := &ScriptedQuestioner{Answers: map[string]interface{}{"daemon.trust_password": "secret"}}
+
+	if q.PasswordConfirm("daemon.trust_password", "") != "secret" {
+		t.Fatal("expected PasswordConfirm to return the scripted answer")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	maasclient "github.com/lxc/lxd/lxd/maas/client"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+)
+
+// initValidationCheck is the outcome of a single dry-run validation step.
+type initValidationCheck struct {
+	Name  string `yaml:"name" json:"name"`
+	OK    bool   `yaml:"ok" json:"ok"`
+	Error string `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// initValidationReport is the machine-readable result of "lxd init --dry-run", printed in the
+// format requested through --format.
+type initValidationReport struct {
+	Success bool                  `yaml:"success" json:"success"`
+	Checks  []initValidationCheck `yaml:"checks" json:"checks"`
+}
+
+// addCheck records the outcome of a single validation step, without aborting the remaining ones:
+// a dry-run should report every problem it can find in one pass, not just the first.
+func (r *initValidationReport) addCheck(name string, err error) {
+	check := initValidationCheck{Name: name}
+
+	if err != nil {
+		check.Error = err.Error()
+		r.Success = false
+	} else {
+		check.OK = true
+	}
+
+	r.Checks = append(r.Checks, check)
+}
+
+// validateConfig checks a fully-populated initData against the target daemon without mutating
+// anything, so that preseed YAMLs can be validated in CI before being rolled out to a fleet.
+func (c *cmdInit) validateConfig(d lxd.ContainerServer, config *initData) *initValidationReport {
+	report := &initValidationReport{Success: true}
+
+	for _, network := range config.Networks {
+		err := networkValidName(network.Name)
+		if err == nil {
+			for _, key := range []string{"ipv4.address", "ipv6.address"} {
+				value, ok := network.Config[key]
+				if !ok || shared.StringInSlice(value, []string{"auto", "none", ""}) {
+					continue
+				}
+
+				if key == "ipv4.address" {
+					err = networkValidAddressCIDRV4(value)
+				} else {
+					err = networkValidAddressCIDRV6(value)
+				}
+
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		report.addCheck(fmt.Sprintf("network %q", network.Name), err)
+	}
+
+	for _, pool := range config.StoragePools {
+		available := c.availableStorageDrivers("all")
+		var err error
+		if !shared.StringInSlice(pool.Driver, available) {
+			err = fmt.Errorf("Storage driver %q is not available on this server", pool.Driver)
+		}
+
+		report.addCheck(fmt.Sprintf("storage pool %q", pool.Name), err)
+	}
+
+	for _, profile := range config.Profiles {
+		for deviceName, device := range profile.Devices {
+			if device["type"] != "nic" || device["parent"] == "" {
+				continue
+			}
+
+			var err error
+			if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", device["parent"])) {
+				err = fmt.Errorf("Parent interface %q does not exist", device["parent"])
+			}
+
+			report.addCheck(fmt.Sprintf("device %q of profile %q", deviceName, profile.Name), err)
+		}
+	}
+
+	maasURL, _ := config.Config["maas.api.url"].(string)
+	if maasURL != "" {
+		maasKey, _ := config.Config["maas.api.key"].(string)
+		report.addCheck("MAAS connectivity", validateMAASConnection(maasURL, maasKey))
+	}
+
+	if config.Cluster != nil && config.Cluster.ClusterAddress != "" {
+		report.addCheck("cluster join", c.validateClusterJoin(config))
+	}
+
+	return report
+}
+
+// validateMAASConnection does a minimal authenticated request against the MAAS API to confirm
+// the URL and key are usable, without changing anything on the MAAS side.
+func validateMAASConnection(url string, key string) error {
+	if key == "" {
+		return fmt.Errorf("No MAAS API key provided")
+	}
+
+	_, err := maasclient.New(url, key).ListSubnets(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateClusterJoin checks that the trust relationship can be established with the token or
+// password the preseed carries, and that the target's pending storage/network configuration is
+// shaped the way this node's config expects.
+//
+// Note that, unlike the rest of validateConfig's checks, this one isn't side-effect-free: the
+// only way to tell a bad join token or stale trust password from a good one is to actually use it
+// to set up the trust relationship, the same way the real join in cmdInit.apply does. A join
+// token is single-use, so running --dry-run against a preseed that carries one consumes it; mint
+// a fresh token before the real join.
+func (c *cmdInit) validateClusterJoin(config *initData) error {
+	cert, err := util.LoadCert(shared.VarPath(""))
+	if err != nil {
+		return err
+	}
+
+	err = cluster.SetupTrust(string(cert.PublicKey()),
+		config.Cluster.ClusterAddress,
+		string(config.Cluster.ClusterCertificate), config.Cluster.ClusterPassword)
+	if err != nil {
+		return fmt.Errorf("Failed to setup trust relationship with cluster: %w", err)
+	}
+
+	args := &lxd.ConnectionArgs{
+		TLSClientCert: string(cert.PublicKey()),
+		TLSClientKey:  string(cert.PrivateKey()),
+		TLSServerCert: string(config.Cluster.ClusterCertificate),
+	}
+
+	client, err := lxd.ConnectLXD(fmt.Sprintf("https://%s", config.Cluster.ClusterAddress), args)
+	if err != nil {
+		return fmt.Errorf("Failed to reach cluster leader: %w", err)
+	}
+
+	targetPools, err := client.GetStoragePools()
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve storage pools from the cluster: %w", err)
+	}
+
+	targetPoolNames := map[string]bool{}
+	for _, pool := range targetPools {
+		targetPoolNames[pool.Name] = true
+	}
+
+	for _, pool := range config.StoragePools {
+		if !targetPoolNames[pool.Name] {
+			return fmt.Errorf("Storage pool %q has no pending definition on the cluster leader", pool.Name)
+		}
+	}
+
+	targetNetworks, err := client.GetNetworks()
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve networks from the cluster: %w", err)
+	}
+
+	targetNetworkNames := map[string]bool{}
+	for _, network := range targetNetworks {
+		targetNetworkNames[network.Name] = true
+	}
+
+	for _, network := range config.Networks {
+		if !targetNetworkNames[network.Name] {
+			return fmt.Errorf("Network %q has no pending definition on the cluster leader", network.Name)
+		}
+	}
+
+	return nil
+}
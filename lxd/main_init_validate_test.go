@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInitValidationReportAddCheckSuccess(t *testing.T) {
+	report := &initValidationReport{Success: true}
+
+	report.addCheck("network \"lxdbr0\"", nil)
+
+	if !report.Success {
+		t.Fatal("expected report to remain successful after a passing check")
+	}
+
+	if len(report.Checks) != 1 || !report.Checks[0].OK || report.Checks[0].Error != "" {
+		t.Fatalf("unexpected check recorded: %+v", report.Checks)
+	}
+}
+
+func TestInitValidationReportAddCheckFailureDoesNotAbort(t *testing.T) {
+	report := &initValidationReport{Success: true}
+
+	report.addCheck("network \"lxdbr0\"", fmt.Errorf("bad address"))
+	report.addCheck("storage pool \"default\"", nil)
+
+	if report.Success {
+		t.Fatal("expected report to be unsuccessful after a failing check")
+	}
+
+	if len(report.Checks) != 2 {
+		t.Fatalf("expected both checks to be recorded even after a failure, got %d", len(report.Checks))
+	}
+
+	if report.Checks[0].OK || report.Checks[0].Error != "bad address" {
+		t.Fatalf("unexpected first check: %+v", report.Checks[0])
+	}
+
+	if !report.Checks[1].OK {
+		t.Fatalf("expected second check to still be recorded as OK, got %+v", report.Checks[1])
+	}
+}
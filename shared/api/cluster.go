@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// ClusterMemberJoinToken represents the fields contained within the opaque join token handed to a
+// node that wants to join an existing cluster through "lxd init".
+//
+// swagger:model
+type ClusterMemberJoinToken struct {
+	// ServerName is the name the new member will use to identify itself in the cluster.
+	// Example: lxd02
+	ServerName string `json:"server_name" yaml:"server_name"`
+
+	// ClusterAddress is the address of an existing cluster member to contact in order to join.
+	// Example: 10.1.1.101:8443
+	ClusterAddress string `json:"cluster_address" yaml:"cluster_address"`
+
+	// Fingerprint is the certificate fingerprint of the target cluster member.
+	// Example: 57bb0ff4340b5bb28517e062023101adf788c3eb0aa82a4fae5eb90012a5dd5
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+
+	// Secret is a random one-time value minted by the target cluster member which authorizes the
+	// join request in lieu of the cluster trust password.
+	// Example: 2b2284d44db032875f96a586fc7ab772d8b4a1e331e9d2f79e8a9600a34d7c4
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+// ClusterMembersPost represents the fields required to request that a cluster leader mint a join
+// token for a prospective new member.
+//
+// swagger:model
+type ClusterMembersPost struct {
+	// ServerName is the name the new member will use to identify itself in the cluster.
+	// Example: lxd02
+	ServerName string `json:"server_name" yaml:"server_name"`
+}
+
+// ClusterPut represents the fields required to enable clustering on a fresh server or have it
+// join an existing cluster.
+//
+// swagger:model
+type ClusterPut struct {
+	// ServerName is the name this server will use to identify itself in the cluster.
+	// Example: lxd02
+	ServerName string `json:"server_name" yaml:"server_name"`
+
+	// Enabled is true when clustering should be turned on for this server.
+	// Example: true
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// ClusterAddress is the address of an existing cluster member to contact in order to join.
+	// It's empty when Enabled is true and ClusterAddress is empty, meaning this server is
+	// bootstrapping a brand new cluster rather than joining one.
+	// Example: 10.1.1.101:8443
+	ClusterAddress string `json:"cluster_address,omitempty" yaml:"cluster_address,omitempty"`
+
+	// ClusterCertificate is the PEM certificate of the target cluster member given in
+	// ClusterAddress.
+	ClusterCertificate string `json:"cluster_certificate,omitempty" yaml:"cluster_certificate,omitempty"`
+
+	// ClusterToken is the join token minted by "lxc cluster add" on the target cluster member, if
+	// any, which authorizes the join request in lieu of ClusterPassword.
+	ClusterToken string `json:"cluster_token,omitempty" yaml:"cluster_token,omitempty"`
+
+	// ClusterPassword is the trust password of the target cluster member given in
+	// ClusterAddress, used to authorize the join request when no ClusterToken was supplied.
+	ClusterPassword string `json:"cluster_password,omitempty" yaml:"cluster_password,omitempty"`
+}
+
+// String encodes the token as the base64 JSON blob that gets printed by "lxc cluster add" and
+// pasted into "lxd init".
+func (t *ClusterMemberJoinToken) String() string {
+	joinTokenJSON, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(joinTokenJSON)
+}
+
+// DecodeClusterMemberJoinToken decodes a join token produced by ClusterMemberJoinToken.String().
+func DecodeClusterMemberJoinToken(input string) (*ClusterMemberJoinToken, error) {
+	joinTokenJSON, err := base64.StdEncoding.DecodeString(input)
+	if err != nil {
+		return nil, err
+	}
+
+	token := ClusterMemberJoinToken{}
+	err = json.Unmarshal(joinTokenJSON, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
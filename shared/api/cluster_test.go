@@ -0,0 +1,28 @@
+package api
+
+import "testing"
+
+func TestClusterMemberJoinTokenRoundTrip(t *testing.T) {
+	token := ClusterMemberJoinToken{
+		ServerName:     "lxd02",
+		ClusterAddress: "10.1.1.101:8443",
+		Fingerprint:    "57bb0ff4340b5bb28517e062023101adf788c3eb0aa82a4fae5eb90012a5dd5",
+		Secret:         "2b2284d44db032875f96a586fc7ab772d8b4a1e331e9d2f79e8a9600a34d7c4",
+	}
+
+	decoded, err := DecodeClusterMemberJoinToken(token.String())
+	if err != nil {
+		t.Fatalf("unexpected error decoding token: %v", err)
+	}
+
+	if *decoded != token {
+		t.Fatalf("expected %+v, got %+v", token, *decoded)
+	}
+}
+
+func TestDecodeClusterMemberJoinTokenInvalidBase64(t *testing.T) {
+	_, err := DecodeClusterMemberJoinToken("not valid base64!!")
+	if err == nil {
+		t.Fatal("expected an error decoding an invalid join token")
+	}
+}